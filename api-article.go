@@ -1,11 +1,38 @@
 /*
    /article/create     GET   [draft (create)]                           no lock
    /article/edit       GET   [version (read) --> draft (create)]        lock on draft
-   /article/save       POST  [draft (update)]                           lock on draft
-   /article/submit     POST  [draft (save/delete) --> version (create)] lock on draft
-   /article/discard    GET   [draft (delete)]                           lock on draft
-   /article/publish    GET   [version (read) --> publish (upsert)]      lock on publish
-   /article/unpublish  GET   [publish (delete)]                         lock on publish
+   /article/save       POST  [draft (update)]                           If-Match etag (_seq_no/_primary_term)
+   /article/submit     POST  [draft (save/delete) --> version (create)] If-Match etag (_seq_no/_primary_term)
+   /article/discard    GET   [draft (delete)]                           If-Match etag (_seq_no/_primary_term)
+   /article/publish    GET   [version (read) --> publish (upsert)]      lock on publish + If-Match etag, optional (upsert if absent)
+   /article/unpublish  GET   [publish (delete)]                         lock on publish + If-Match etag (_seq_no/_primary_term)
+
+   /article/schedule-publish    POST [version (update publish_at)]      lock on publish
+   /article/schedule-unpublish  POST [publish (update unpublish_at)]    lock on publish
+
+   /article/history  GET [version (search)]                 no lock
+   /article/diff     GET [version (read) x2]                no lock
+
+   /article/bulk-publish    POST [version (read) x N --> publish (_bulk upsert)] sorted locks on publish
+   /article/bulk-unpublish  POST [publish (_bulk delete)]                        sorted locks on publish
+   /article/bulk-discard    POST [draft (_bulk delete)]                          sorted locks on draft
+
+   create/save/submit/discard/edit/publish/unpublish/bulk-publish/
+   bulk-unpublish/bulk-discard each write a lifecycle event
+   (article.created, article.saved, ...) to the article_events outbox
+   index (per id, for the bulk endpoints); RunArticleEventDispatcher
+   delivers them to app.EventSinks (HMAC-signed webhooks, and optionally
+   a Kafka/NATS topic) with retry/backoff, see emitArticleEvent and
+   deliverEvent. A row that still fails after ArticleEventMaxAttempts is
+   dead-lettered (dead_lettered_at set) rather than retried forever.
+
+   every endpoint above bounds its ES calls to ctx derived from
+   r.Context() (see endpointContext), so a client disconnect or a slow
+   cluster cancels in-flight work instead of leaking it; a
+   DeadlineExceeded/Canceled ctx error surfaces as a typed 504/499
+   response (see ctxErrorResp) instead of a generic 500. That root is
+   itself app.ShutdownCtx via the http.Server's BaseContext, so
+   AppRuntime.Shutdown draining it cancels everything in flight too.
 */
 
 package main
@@ -16,12 +43,16 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	//elastic "gopkg.in/olivere/elastic.v5"
 	elastic "github.com/yizha/elastic"
+	"github.com/yizha/story-api/apierr"
+	"github.com/yizha/story-api/events"
 )
 
 const (
@@ -35,6 +66,70 @@ if (params.checkuser && ctx._source.locked_by != params.username) {
   ctx._source.tag = params.tag;
   ctx._source.note = params.note;
 }`
+
+	ESScriptSetPublishAt = `ctx._source.publish_at = params.publish_at;`
+
+	ESScriptSetUnpublishAt = `ctx._source.unpublish_at = params.unpublish_at;`
+
+	// clear the scheduled field only if it still matches what the scheduler
+	// observed, so a user re-scheduling after the scan started isn't clobbered
+	ESScriptClearPublishAt = `
+if (ctx._source.publish_at == params.expected) {
+  ctx._source.publish_at = null;
+} else {
+  ctx.op = "none";
+}`
+
+	ESScriptClearUnpublishAt = `
+if (ctx._source.unpublish_at == params.expected) {
+  ctx._source.unpublish_at = null;
+} else {
+  ctx.op = "none";
+}`
+
+	ESScriptMarkEventDelivered = `ctx._source.delivered_at = params.now;`
+
+	ESScriptRetryEvent = `
+ctx._source.attempts = params.attempts;
+ctx._source.next_attempt_at = params.next_attempt_at;`
+
+	// dead-letters a row once it's exhausted ArticleEventMaxAttempts, so
+	// scanPendingEvents stops picking it up; it's left in the index for
+	// inspection rather than deleted.
+	ESScriptDeadLetterEvent = `
+ctx._source.attempts = params.attempts;
+ctx._source.dead_lettered_at = params.now;`
+)
+
+// how often the scheduler scans for due publish_at/unpublish_at entries,
+// and how many due entries it processes per scan
+const (
+	ArticleSchedulerDefaultInterval = 30 * time.Second
+	ArticleSchedulerBatchSize       = 100
+)
+
+const (
+	ArticleHistoryDefaultPageSize = 20
+	ArticleHistoryMaxPageSize     = 200
+)
+
+const (
+	BulkDefaultWorkers    = 4
+	BulkDefaultActions    = 500
+	BulkDefaultBackoffMin = 100 * time.Millisecond
+	BulkDefaultBackoffMax = 30 * time.Second
+)
+
+// how often the event dispatcher scans the article_events outbox for
+// undelivered rows, how many it picks up per scan, and how many delivery
+// attempts it makes against its configured sinks before dead-lettering
+// a row (see deliverEvent)
+const (
+	ArticleEventDispatcherDefaultInterval = 10 * time.Second
+	ArticleEventDispatcherBatchSize       = 100
+	ArticleEventMaxAttempts               = 8
+	ArticleEventBackoffMin                = 1 * time.Second
+	ArticleEventBackoffMax                = 10 * time.Minute
 )
 
 var (
@@ -42,6 +137,25 @@ var (
 	publishLock = &UniqStrMutex{}
 )
 
+// default per-endpoint ES deadlines, used when the matching
+// app.Conf.Timeouts.* field isn't configured (<= 0)
+const (
+	DefaultCreateTimeout            = 5 * time.Second
+	DefaultSaveTimeout              = 5 * time.Second
+	DefaultSubmitTimeout            = 10 * time.Second
+	DefaultDiscardTimeout           = 5 * time.Second
+	DefaultEditTimeout              = 5 * time.Second
+	DefaultPublishTimeout           = 10 * time.Second
+	DefaultUnpublishTimeout         = 5 * time.Second
+	DefaultHistoryTimeout           = 5 * time.Second
+	DefaultDiffTimeout              = 5 * time.Second
+	DefaultSchedulePublishTimeout   = 5 * time.Second
+	DefaultScheduleUnpublishTimeout = 5 * time.Second
+	DefaultBulkDiscardTimeout       = 30 * time.Second
+	DefaultBulkUnpublishTimeout     = 30 * time.Second
+	DefaultBulkPublishTimeout       = 30 * time.Second
+)
+
 type JSONTime struct {
 	T time.Time
 }
@@ -82,6 +196,11 @@ type Article struct {
 	RevisedBy   string    `json:"revised_by,omitempty"`
 	FromVersion int64     `json:"from_version,omitempty"`
 	LockedBy    string    `json:"locked_by,omitempty"`
+	PublishAt   *JSONTime `json:"publish_at,omitempty"`
+	UnpublishAt *JSONTime `json:"unpublish_at,omitempty"`
+	ETag        string    `json:"etag,omitempty"`
+	SeqNo       int64     `json:"-"`
+	PrimaryTerm int64     `json:"-"`
 }
 
 func (a *Article) NilZeroTimeFields() *Article {
@@ -91,9 +210,155 @@ func (a *Article) NilZeroTimeFields() *Article {
 	if a.RevisedAt != nil && a.RevisedAt.T.IsZero() {
 		a.RevisedAt = nil
 	}
+	if a.PublishAt != nil && a.PublishAt.T.IsZero() {
+		a.PublishAt = nil
+	}
+	if a.UnpublishAt != nil && a.UnpublishAt.T.IsZero() {
+		a.UnpublishAt = nil
+	}
 	return a
 }
 
+// etagFor encodes an ES _seq_no/_primary_term pair as the opaque token
+// handed to clients; parseEtag reverses it. Clients must treat it as
+// opaque and round-trip it via the If-Match header on mutating calls.
+func etagFor(seqNo, primaryTerm int64) string {
+	return fmt.Sprintf("%d.%d", seqNo, primaryTerm)
+}
+
+func parseEtag(etag string) (int64, int64, error) {
+	parts := strings.SplitN(etag, ".", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("malformed etag: %v", etag)
+	}
+	seqNo, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed etag: %v", etag)
+	}
+	primaryTerm, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed etag: %v", etag)
+	}
+	return seqNo, primaryTerm, nil
+}
+
+// requireIfMatch extracts and parses the If-Match header carrying the
+// etag a client obtained from a prior read. save, submit, discard and
+// unpublish require it (unpublish still also takes publishLock);
+// publish parses If-Match itself, since there it is optional.
+func requireIfMatch(r *http.Request) (int64, int64, *HttpResponseData) {
+	etag := r.Header.Get("If-Match")
+	if etag == "" {
+		return 0, 0, apiErrorResp(apierr.ValidationFailed, "If-Match header is required!")
+	}
+	seqNo, primaryTerm, err := parseEtag(etag)
+	if err != nil {
+		return 0, 0, apiErrorResp(apierr.ValidationFailed, fmt.Sprintf("invalid If-Match header: %v", err))
+	}
+	return seqNo, primaryTerm, nil
+}
+
+// etagRespData is returned by mutating endpoints on success so the
+// caller learns the new etag without having to re-fetch the article.
+func etagRespData(seqNo, primaryTerm int64) *HttpResponseData {
+	bytes, err := json.Marshal(map[string]string{"etag": etagFor(seqNo, primaryTerm)})
+	if err != nil {
+		return apiErrorResp(apierr.Internal, fmt.Sprintf("error marshaling etag: %v", err))
+	}
+	return CreateRespData(http.StatusOK, ContentTypeValueJSON, string(bytes))
+}
+
+// endpointContext derives a context from the request's own context, so
+// a client disconnect cancels whatever ES call is in flight, bounded by
+// timeout (falling back to def when the endpoint's app.Conf.Timeouts
+// field isn't configured). r.Context() is itself derived from
+// app.ShutdownCtx via the http.Server's BaseContext, so canceling that
+// root on AppRuntime.Shutdown drains every in-flight call here too.
+func endpointContext(r *http.Request, timeout, def time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = def
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// ctxErrorResp maps a context cancellation/deadline error observed on
+// an ES call to the typed response the client should see instead of a
+// generic 500; it returns nil when ctx wasn't the cause, so callers can
+// fall through to their normal error handling.
+func ctxErrorResp(ctx context.Context) *HttpResponseData {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return apiErrorResp(apierr.DeadlineExceeded, "request timed out")
+	case context.Canceled:
+		return apiErrorResp(apierr.Canceled, "request canceled")
+	default:
+		return nil
+	}
+}
+
+// apiErrorResp builds the typed {"error":{"code":...}} envelope for code
+// and message and wraps it in an HttpResponseData at the code's mapped
+// HTTP status. The *apierr.Error is stashed in Data so addAuditLogFields
+// can record the code without re-parsing the body.
+func apiErrorResp(code apierr.Code, message string) *HttpResponseData {
+	e := apierr.New(code, message)
+	bytes, err := e.MarshalEnvelope()
+	if err != nil {
+		bytes = []byte(`{"error":{"code":"INTERNAL","message":"failed to marshal error response"}}`)
+	}
+	d := CreateRespData(e.HTTPStatus(), ContentTypeValueJSON, string(bytes))
+	d.Data = e
+	return d
+}
+
+// conflictRespData re-fetches the current document so a 409 response
+// carries the fresh etag the caller needs to retry.
+func conflictRespData(app *AppRuntime, ctx context.Context, typ, id string) *HttpResponseData {
+	source := elastic.NewFetchSourceContext(true).Include("guid")
+	article, d := getArticle(app.Elastic.Client, ctx, app.Conf.ArticleIndex.Name, typ, id, source)
+	if d != nil {
+		return d
+	}
+	return marshalArticle(article, http.StatusConflict)
+}
+
+// ArticleEventEmitTimeout bounds the outbox write emitArticleEvent makes
+// on its own short-lived context: callers invoke it after their own ES
+// call already succeeded, so it must never inherit a caller ctx that may
+// already be close to its deadline.
+const ArticleEventEmitTimeout = 3 * time.Second
+
+// emitArticleEvent writes e to the article_events outbox index so it
+// can be delivered to the configured sinks (webhooks, and optionally a
+// Kafka/NATS topic) independently of the request that triggered it.
+// RunArticleEventDispatcher picks up undelivered rows and retries them
+// with backoff; a failure to persist here is logged and swallowed, since
+// losing an event must never fail or block the editorial operation that
+// produced it. It runs on its own ArticleEventEmitTimeout-bounded
+// context rather than the caller's, since it's invoked after the
+// caller's own ES call already succeeded.
+func emitArticleEvent(app *AppRuntime, eventType, guid string, version int64, actor, etag string) {
+	ctx, cancel := context.WithTimeout(app.ShutdownCtx, ArticleEventEmitTimeout)
+	defer cancel()
+	now := time.Now().UTC()
+	e := &events.Event{
+		Type:          eventType,
+		GUID:          guid,
+		Version:       version,
+		Actor:         actor,
+		Timestamp:     now,
+		ETag:          etag,
+		NextAttemptAt: now,
+	}
+	idxService := app.Elastic.Client.Index()
+	idxService.Index(app.Conf.ArticleEventsIndex.Name)
+	idxService.Type(app.Conf.ArticleEventsIndex.Type)
+	idxService.BodyJson(e)
+	if _, err := idxService.Do(ctx); err != nil {
+		app.Logger.Perrorf("failed to persist %v event for %v, error: %v", eventType, guid, err)
+	}
+}
+
 func unmarshalArticle(data []byte) (*Article, error) {
 	var a Article
 	err := json.Unmarshal(data, &a)
@@ -121,6 +386,8 @@ func getFullArticle(
 		"from_version",
 		"note",
 		"locked_by",
+		"publish_at",
+		"unpublish_at",
 	)
 	return getArticle(client, ctx, index, typ, id, source)
 }
@@ -140,21 +407,26 @@ func getArticle(
 	if err != nil {
 		if elastic.IsNotFound(err) {
 			body := fmt.Sprintf("article %v not found in index %v type %v!", id, index, typ)
-			return nil, CreateNotFoundRespData(body)
+			return nil, apiErrorResp(apierr.NotFound, body)
+		} else if d := ctxErrorResp(ctx); d != nil {
+			return nil, d
 		} else {
 			body := fmt.Sprintf("failed to query elasticsearch, error: %v", err)
-			return nil, CreateInternalServerErrorRespData(body)
+			return nil, apiErrorResp(apierr.Internal, body)
 		}
 	} else if !resp.Found {
 		body := fmt.Sprintf("article %v not found in index %v type %v!", id, index, typ)
-		return nil, CreateNotFoundRespData(body)
+		return nil, apiErrorResp(apierr.NotFound, body)
 	} else {
 		article := &Article{}
 		if err := json.Unmarshal(*resp.Source, article); err != nil {
 			body := fmt.Sprintf("unmarshal article error: %v", err)
-			return nil, CreateInternalServerErrorRespData(body)
+			return nil, apiErrorResp(apierr.Internal, body)
 		} else {
 			article.Id = resp.Id
+			article.SeqNo = resp.SeqNo
+			article.PrimaryTerm = resp.PrimaryTerm
+			article.ETag = etagFor(resp.SeqNo, resp.PrimaryTerm)
 			return article, nil
 		}
 	}
@@ -164,7 +436,7 @@ func marshalArticle(a *Article, status int) *HttpResponseData {
 	bytes, err := json.Marshal(a)
 	if err != nil {
 		body := fmt.Sprintf("error marshaling article: %v", err)
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	} else {
 		return CreateRespData(status, ContentTypeValueJSON, string(bytes))
 	}
@@ -185,6 +457,311 @@ func parseArticleId(id string) (string, int64, error) {
 	}
 }
 
+// ArticleHistoryItem is the metadata-only projection of a version
+// returned by /article/history; it omits the content body (and tags)
+// so a page of history stays cheap to fetch.
+type ArticleHistoryItem struct {
+	Version   int64     `json:"version"`
+	Headline  string    `json:"headline,omitempty"`
+	Summary   string    `json:"summary,omitempty"`
+	RevisedBy string    `json:"revised_by,omitempty"`
+	RevisedAt *JSONTime `json:"revised_at,omitempty"`
+	Note      string    `json:"note,omitempty"`
+}
+
+type ArticleHistoryPage struct {
+	Items       []*ArticleHistoryItem `json:"items"`
+	SearchAfter string                `json:"search_after,omitempty"`
+}
+
+type DiffOp string
+
+const (
+	DiffOpEq  DiffOp = "eq"
+	DiffOpIns DiffOp = "ins"
+	DiffOpDel DiffOp = "del"
+)
+
+type DiffRun struct {
+	Op   DiffOp `json:"op"`
+	Text string `json:"text"`
+}
+
+type TagDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+type ArticleDiff struct {
+	Headline []*DiffRun `json:"headline,omitempty"`
+	Summary  []*DiffRun `json:"summary,omitempty"`
+	Content  []*DiffRun `json:"content,omitempty"`
+	Tag      *TagDiff   `json:"tag,omitempty"`
+}
+
+// diffLines returns a Myers line diff of a and b as {op, text} runs,
+// merging consecutive lines with the same op into a single run.
+func diffLines(a, b string) []*DiffRun {
+	if a == b {
+		if a == "" {
+			return nil
+		}
+		return []*DiffRun{{Op: DiffOpEq, Text: a}}
+	}
+	return myersDiffLines(strings.Split(a, "\n"), strings.Split(b, "\n"))
+}
+
+// myersDiffLines implements the classic Myers O(ND) shortest-edit-script
+// algorithm over lines and reconstructs the edit script by backtracking
+// through the saved search frontiers.
+func myersDiffLines(a, b []string) []*DiffRun {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+	v := map[int]int{1: 0}
+	trace := make([]map[int]int, 0, max+1)
+	d := 0
+	for ; d <= max; d++ {
+		snapshot := make(map[int]int, len(v))
+		for k, val := range v {
+			snapshot[k] = val
+		}
+		trace = append(trace, snapshot)
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1] < v[k+1]) {
+				x = v[k+1]
+			} else {
+				x = v[k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	type point struct{ x, y int }
+	path := []point{{n, m}}
+	x, y := n, m
+	for ; d > 0; d-- {
+		vv := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && vv[k-1] < vv[k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := vv[prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			path = append(path, point{x, y})
+		}
+		if x == prevX {
+			y--
+		} else {
+			x--
+		}
+		path = append(path, point{x, y})
+		x, y = prevX, prevY
+	}
+	// d==0's own snake (the matching prefix from (0,0), if any) is never
+	// walked by the loop above since it has no prior diagonal to
+	// backtrack from; finish it here before reversing path.
+	for x > 0 && y > 0 {
+		x--
+		y--
+		path = append(path, point{x, y})
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	runs := make([]*DiffRun, 0, len(path))
+	for i := 1; i < len(path); i++ {
+		px, py := path[i-1].x, path[i-1].y
+		cx, cy := path[i].x, path[i].y
+		if cx == px+1 && cy == py+1 {
+			runs = append(runs, &DiffRun{Op: DiffOpEq, Text: a[px]})
+		} else if cx == px+1 {
+			runs = append(runs, &DiffRun{Op: DiffOpDel, Text: a[px]})
+		} else if cy == py+1 {
+			runs = append(runs, &DiffRun{Op: DiffOpIns, Text: b[py]})
+		}
+	}
+	return mergeDiffRuns(runs)
+}
+
+func mergeDiffRuns(runs []*DiffRun) []*DiffRun {
+	if len(runs) == 0 {
+		return nil
+	}
+	merged := make([]*DiffRun, 0, len(runs))
+	cur := &DiffRun{Op: runs[0].Op, Text: runs[0].Text}
+	for _, run := range runs[1:] {
+		if run.Op == cur.Op {
+			cur.Text = cur.Text + "\n" + run.Text
+		} else {
+			merged = append(merged, cur)
+			cur = &DiffRun{Op: run.Op, Text: run.Text}
+		}
+	}
+	merged = append(merged, cur)
+	return merged
+}
+
+func diffTags(from, to []string) *TagDiff {
+	fromSet := make(map[string]bool, len(from))
+	for _, t := range from {
+		fromSet[t] = true
+	}
+	toSet := make(map[string]bool, len(to))
+	for _, t := range to {
+		toSet[t] = true
+	}
+	var added, removed []string
+	for _, t := range to {
+		if !fromSet[t] {
+			added = append(added, t)
+		}
+	}
+	for _, t := range from {
+		if !toSet[t] {
+			removed = append(removed, t)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return nil
+	}
+	return &TagDiff{Added: added, Removed: removed}
+}
+
+// articleHistory pages over versions of a guid, newest first, using ES
+// search_after so deep pages don't pay the cost of a from/size scan.
+func articleHistory(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
+	guid := strings.TrimSpace(r.URL.Query().Get("guid"))
+	if guid == "" {
+		return apiErrorResp(apierr.ValidationFailed, "guid is required!")
+	}
+	size := ArticleHistoryDefaultPageSize
+	if s := r.URL.Query().Get("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			return apiErrorResp(apierr.ValidationFailed, "invalid size!")
+		}
+		if n > ArticleHistoryMaxPageSize {
+			n = ArticleHistoryMaxPageSize
+		}
+		size = n
+	}
+	var searchAfter []interface{}
+	if sa := r.URL.Query().Get("search_after"); sa != "" {
+		ver, err := strconv.ParseInt(sa, 10, 64)
+		if err != nil {
+			return apiErrorResp(apierr.ValidationFailed, "invalid search_after!")
+		}
+		searchAfter = []interface{}{ver}
+	}
+	source := elastic.NewFetchSourceContext(true).Include(
+		"version",
+		"headline",
+		"summary",
+		"revised_by",
+		"revised_at",
+		"note",
+	)
+	searchService := app.Elastic.Client.Search()
+	searchService.Index(app.Conf.ArticleIndex.Name)
+	searchService.Type(app.Conf.ArticleIndexTypes.Version)
+	searchService.Query(elastic.NewTermQuery("guid", guid))
+	searchService.FetchSourceContext(source)
+	searchService.Sort("version", false)
+	searchService.Size(size)
+	if len(searchAfter) > 0 {
+		searchService.SearchAfter(searchAfter...)
+	}
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.History, DefaultHistoryTimeout)
+	defer cancel()
+	resp, err := searchService.Do(ctx)
+	if err != nil {
+		if d := ctxErrorResp(ctx); d != nil {
+			return d
+		}
+		body := fmt.Sprintf("failed to query elasticsearch, error: %v", err)
+		return apiErrorResp(apierr.Internal, body)
+	}
+	items := make([]*ArticleHistoryItem, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		item := &ArticleHistoryItem{}
+		if err := json.Unmarshal(*hit.Source, item); err != nil {
+			body := fmt.Sprintf("failed to unmarshal history entry, error: %v", err)
+			return apiErrorResp(apierr.Internal, body)
+		}
+		items = append(items, item)
+	}
+	page := &ArticleHistoryPage{Items: items}
+	if len(items) == size {
+		page.SearchAfter = fmt.Sprintf("%v", items[len(items)-1].Version)
+	}
+	bytes, err := json.Marshal(page)
+	if err != nil {
+		body := fmt.Sprintf("error marshaling history page: %v", err)
+		return apiErrorResp(apierr.Internal, body)
+	}
+	return CreateRespData(http.StatusOK, ContentTypeValueJSON, string(bytes))
+}
+
+// articleDiff loads two full versions (ids of the form guid:version) and
+// returns a structured diff of headline/summary/content plus a set-diff
+// of tag, so the write-once version type doubles as a revision browser.
+func articleDiff(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		return apiErrorResp(apierr.ValidationFailed, "from and to are required!")
+	}
+	client := app.Elastic.Client
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.Diff, DefaultDiffTimeout)
+	defer cancel()
+	index := app.Conf.ArticleIndex.Name
+	typ := app.Conf.ArticleIndexTypes.Version
+	fromArticle, d := getFullArticle(client, ctx, index, typ, from)
+	if d != nil {
+		return d
+	}
+	toArticle, d := getFullArticle(client, ctx, index, typ, to)
+	if d != nil {
+		return d
+	}
+	diff := &ArticleDiff{
+		Headline: diffLines(fromArticle.Headline, toArticle.Headline),
+		Summary:  diffLines(fromArticle.Summary, toArticle.Summary),
+		Content:  diffLines(fromArticle.Content, toArticle.Content),
+		Tag:      diffTags(fromArticle.Tag, toArticle.Tag),
+	}
+	bytes, err := json.Marshal(diff)
+	if err != nil {
+		body := fmt.Sprintf("error marshaling article diff: %v", err)
+		return apiErrorResp(apierr.Internal, body)
+	}
+	return CreateRespData(http.StatusOK, ContentTypeValueJSON, string(bytes))
+}
+
 func addAuditLogFields(action string, h EndpointHandler) EndpointHandler {
 	return func(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
 		d := h(app, w, r)
@@ -198,6 +775,9 @@ func addAuditLogFields(action string, h EndpointHandler) EndpointHandler {
 		fields["audit"] = "article"
 		fields["action"] = action
 		fields["user"] = AuthFromReq(r).Username
+		if apiErr, ok := d.Data.(*apierr.Error); ok {
+			fields["error_code"] = string(apiErr.Code)
+		}
 		if id != "" {
 			guid, ver, err := parseArticleId(id)
 			if err == nil {
@@ -225,24 +805,31 @@ func createArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *Htt
 	idxService.Index(app.Conf.ArticleIndex.Name)
 	idxService.Type(app.Conf.ArticleIndexTypes.Draft)
 	idxService.BodyJson(article)
-	resp, err := idxService.Do(context.Background())
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.Create, DefaultCreateTimeout)
+	defer cancel()
+	resp, err := idxService.Do(ctx)
 	if err != nil {
+		if d := ctxErrorResp(ctx); d != nil {
+			return d
+		}
 		body := fmt.Sprintf("error creating new doc: %v", err)
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	} else {
 		article.Id = resp.Id
 		article.Guid = resp.Id
 		article.CreatedBy = username
 		article.LockedBy = username
+		article.ETag = etagFor(resp.SeqNo, resp.PrimaryTerm)
 		if bytes, err := json.Marshal(article); err == nil {
 			d := CreateRespData(http.StatusOK, ContentTypeValueJSON, string(bytes))
 			// save article so that we can log auto-generated article-id
 			// with context-logger
 			d.Data = article
+			emitArticleEvent(app, events.ArticleCreated, article.Guid, 0, username, article.ETag)
 			return d
 		} else {
 			body := fmt.Sprintf("failed to marshal Article object, error: %v", err)
-			return CreateInternalServerErrorRespData(body)
+			return apiErrorResp(apierr.Internal, body)
 		}
 	}
 }
@@ -252,15 +839,19 @@ func saveArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpR
 	bytes, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		body := fmt.Sprintf("failed to read request body, error: %v", err)
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	}
 	article, err := unmarshalArticle(bytes)
 	if err != nil {
 		body := fmt.Sprintf("failed to unmarshal article, error: %v", err)
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	}
 	id := StringFromReq(r, CtxKeyId)
 	username := AuthFromReq(r).Username
+	seqNo, primaryTerm, d := requireIfMatch(r)
+	if d != nil {
+		return d
+	}
 	script := elastic.NewScript(ESScriptSaveArticle)
 	script.Type("inline").Lang("painless").Params(map[string]interface{}{
 		"checkuser":  true,
@@ -278,29 +869,34 @@ func saveArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpR
 	updService.Id(id)
 	updService.Script(script)
 	updService.DetectNoop(true)
+	updService.IfSeqNo(seqNo)
+	updService.IfPrimaryTerm(primaryTerm)
 
-	lock := draftLock.Get(id)
-	lock.Lock()
-	defer lock.Unlock()
-	resp, err := updService.Do(context.Background())
-	//fmt.Printf("resp: %T, %+v\n", resp, resp)
-	//fmt.Printf("error: %v\n", err)
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.Save, DefaultSaveTimeout)
+	defer cancel()
+	resp, err := updService.Do(ctx)
 	if err != nil {
-		if elastic.IsNotFound(err) {
+		if elastic.IsConflict(err) {
+			return conflictRespData(app, ctx, app.Conf.ArticleIndexTypes.Draft, id)
+		} else if elastic.IsNotFound(err) {
 			body := fmt.Sprintf("article %v not found!", id)
-			return CreateNotFoundRespData(body)
+			return apiErrorResp(apierr.NotFound, body)
+		} else if d := ctxErrorResp(ctx); d != nil {
+			return d
 		} else {
 			body := fmt.Sprintf("failed to update article, error: %v", err)
-			return CreateInternalServerErrorRespData(body)
+			return apiErrorResp(apierr.Internal, body)
 		}
 	} else {
 		if resp.Result == "noop" {
-			return CreateForbiddenRespData("Update article locked by another user is not allowed!")
+			return apiErrorResp(apierr.LockedByOther, "Update article locked by another user is not allowed!")
 		} else if resp.Result == "updated" {
-			return CreateRespData(http.StatusOK, ContentTypeValueText, "")
+			etag := etagFor(resp.SeqNo, resp.PrimaryTerm)
+			emitArticleEvent(app, events.ArticleSaved, id, 0, username, etag)
+			return etagRespData(resp.SeqNo, resp.PrimaryTerm)
 		} else {
 			body := fmt.Sprintf(`unknown "result" in update response: %v`, resp.Result)
-			return CreateInternalServerErrorRespData(body)
+			return apiErrorResp(apierr.Internal, body)
 		}
 	}
 }
@@ -312,15 +908,19 @@ func submitArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *Htt
 	bytes, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		body := fmt.Sprintf("failed to read request body, error: %v", err)
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	}
 	article, err := unmarshalArticle(bytes)
 	if err != nil {
 		body := fmt.Sprintf("failed to unmarshal article, error: %v", err)
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	}
 	guid := StringFromReq(r, CtxKeyId)
 	username := AuthFromReq(r).Username
+	seqNo, primaryTerm, d := requireIfMatch(r)
+	if d != nil {
+		return d
+	}
 	ts := time.Now().UTC()
 	jt := &JSONTime{ts}
 	script := elastic.NewScript(ESScriptSaveArticle)
@@ -341,15 +941,20 @@ func submitArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *Htt
 	updService.Id(guid)
 	updService.Script(script)
 	updService.DetectNoop(false)
-	ctx := context.Background()
+	updService.IfSeqNo(seqNo)
+	updService.IfPrimaryTerm(primaryTerm)
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.Submit, DefaultSubmitTimeout)
+	defer cancel()
 
-	lock := draftLock.Get(guid)
-	lock.Lock()
-	defer lock.Unlock()
-	_, err = updService.Do(ctx)
+	updResp, err := updService.Do(ctx)
 	if err != nil {
+		if elastic.IsConflict(err) {
+			return conflictRespData(app, ctx, app.Conf.ArticleIndexTypes.Draft, guid)
+		} else if d := ctxErrorResp(ctx); d != nil {
+			return d
+		}
 		body := fmt.Sprintf("failed to save article draft %v, error: %v", guid, err)
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	}
 	// set article props for the new version
 	ver := ts.UnixNano()
@@ -375,22 +980,33 @@ func submitArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *Htt
 	idxService.BodyJson(article)
 	idxResp, err := idxService.Do(ctx)
 	if err != nil {
+		if d := ctxErrorResp(ctx); d != nil {
+			return d
+		}
 		body := fmt.Sprintf("failed to create new article version, error: %v", err)
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	} else if !idxResp.Created {
 		body := "no reason but article new version is not created!"
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	}
-	// delete article from draft
+	// delete article from draft, pinned to the version we just wrote so a
+	// concurrent edit landing between the update and this delete is caught
+	// instead of silently dropped
 	delService := client.Delete()
 	delService.Index(app.Conf.ArticleIndex.Name)
 	delService.Type(app.Conf.ArticleIndexTypes.Draft)
 	delService.Id(article.Guid)
+	delService.IfSeqNo(updResp.SeqNo)
+	delService.IfPrimaryTerm(updResp.PrimaryTerm)
 	_, err = delService.Do(ctx)
 	if err != nil {
+		if d := ctxErrorResp(ctx); d != nil {
+			return d
+		}
 		body := fmt.Sprintf("failed to delete article draft, error: %v", err)
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	}
+	emitArticleEvent(app, events.ArticleSubmitted, article.Guid, article.Version, username, etagFor(idxResp.SeqNo, idxResp.PrimaryTerm))
 	// return article version
 	article.Headline = ""
 	article.Summary = ""
@@ -402,24 +1018,35 @@ func submitArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *Htt
 
 func discardArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
 	id := StringFromReq(r, CtxKeyId)
+	username := AuthFromReq(r).Username
+	seqNo, primaryTerm, d := requireIfMatch(r)
+	if d != nil {
+		return d
+	}
 	delService := app.Elastic.Client.Delete()
 	delService.Index(app.Conf.ArticleIndex.Name)
 	delService.Type(app.Conf.ArticleIndexTypes.Draft)
 	delService.Id(id)
+	delService.IfSeqNo(seqNo)
+	delService.IfPrimaryTerm(primaryTerm)
 
-	lock := draftLock.Get(id)
-	lock.Lock()
-	defer lock.Unlock()
-	_, err := delService.Do(context.Background())
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.Discard, DefaultDiscardTimeout)
+	defer cancel()
+	_, err := delService.Do(ctx)
 	if err != nil {
-		if elastic.IsNotFound(err) {
+		if elastic.IsConflict(err) {
+			return conflictRespData(app, ctx, app.Conf.ArticleIndexTypes.Draft, id)
+		} else if elastic.IsNotFound(err) {
 			body := fmt.Sprintf("article %v not found!", id)
-			return CreateNotFoundRespData(body)
+			return apiErrorResp(apierr.NotFound, body)
+		} else if d := ctxErrorResp(ctx); d != nil {
+			return d
 		} else {
 			body := fmt.Sprintf("failed to discard article, error: %v", err)
-			return CreateInternalServerErrorRespData(body)
+			return apiErrorResp(apierr.Internal, body)
 		}
 	} else {
+		emitArticleEvent(app, events.ArticleDiscarded, id, 0, username, "")
 		return CreateRespData(http.StatusOK, ContentTypeValueText, "")
 	}
 }
@@ -430,7 +1057,8 @@ func editArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpR
 	client := app.Elastic.Client
 	index := app.Conf.ArticleIndex.Name
 	typ := app.Conf.ArticleIndexTypes.Version
-	ctx := context.Background()
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.Edit, DefaultEditTimeout)
+	defer cancel()
 	article, d := getFullArticle(client, ctx, index, typ, id)
 	if d != nil {
 		return d
@@ -451,13 +1079,19 @@ func editArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpR
 	idxService.Id(article.Guid)
 	idxService.BodyJson(article)
 
+	// draftLock only needs to guard the create call itself (against a
+	// concurrent edit of the same guid); it's released as soon as that
+	// call returns so the outbox write below can't stall it.
 	lock := draftLock.Get(article.Guid)
 	lock.Lock()
-	defer lock.Unlock()
 	resp, err := idxService.Do(ctx)
+	lock.Unlock()
 	if err != nil {
+		if d := ctxErrorResp(ctx); d != nil {
+			return d
+		}
 		body := fmt.Sprintf("error querying elasticsearch, error: %v", err)
-		return CreateInternalServerErrorRespData(body)
+		return apiErrorResp(apierr.Internal, body)
 	} else if !resp.Created {
 		// same doc already there? try to load it
 		source := elastic.NewFetchSourceContext(true).Include("guid", "version", "from_version", "locked_by")
@@ -475,17 +1109,23 @@ func editArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpR
 			return marshalArticle(article, http.StatusConflict)
 		}
 	} else {
+		article.ETag = etagFor(resp.SeqNo, resp.PrimaryTerm)
+		emitArticleEvent(app, events.ArticleEdited, article.Guid, article.FromVersion, user, article.ETag)
 		return marshalArticle(article, http.StatusOK)
 	}
 }
 
-func publishArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
-	// load article from version
+// publishArticleVersion loads the given version and upserts it into the
+// publish type. When ifSeqNo/ifPrimaryTerm are non-nil the update is
+// pinned to that revision of the publish doc (the /article/publish
+// handler's path, guarded by the caller's If-Match token); otherwise it
+// upserts unconditionally (the scheduler's path, which has no client
+// token and instead serializes via publishLock). actor is recorded on
+// the resulting article.published event ("scheduler" for the latter).
+func publishArticleVersion(app *AppRuntime, ctx context.Context, id string, actor string, ifSeqNo, ifPrimaryTerm *int64) *HttpResponseData {
 	client := app.Elastic.Client
-	ctx := context.Background()
 	index := app.Conf.ArticleIndex.Name
 	typ := app.Conf.ArticleIndexTypes.Version
-	id := StringFromReq(r, CtxKeyId)
 	article, d := getFullArticle(client, ctx, index, typ, id)
 	if d != nil {
 		return d
@@ -499,41 +1139,768 @@ func publishArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *Ht
 	updService.Type(app.Conf.ArticleIndexTypes.Publish)
 	updService.Id(guid)
 	updService.Doc(article)
-	updService.DocAsUpsert(true)
+	if ifSeqNo != nil && ifPrimaryTerm != nil {
+		updService.IfSeqNo(*ifSeqNo)
+		updService.IfPrimaryTerm(*ifPrimaryTerm)
+	} else {
+		updService.DocAsUpsert(true)
+	}
+	resp, err := updService.Do(ctx)
+	if err != nil {
+		if elastic.IsConflict(err) {
+			return conflictRespData(app, ctx, app.Conf.ArticleIndexTypes.Publish, guid)
+		} else if d := ctxErrorResp(ctx); d != nil {
+			return d
+		}
+		body := fmt.Sprintf("failed to publish article, error: %v", err)
+		return apiErrorResp(apierr.Internal, body)
+	} else {
+		etag := etagFor(resp.SeqNo, resp.PrimaryTerm)
+		emitArticleEvent(app, events.ArticlePublished, guid, article.Version, actor, etag)
+		return etagRespData(resp.SeqNo, resp.PrimaryTerm)
+	}
+}
 
+func publishArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
+	id := StringFromReq(r, CtxKeyId)
+	username := AuthFromReq(r).Username
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.Publish, DefaultPublishTimeout)
+	defer cancel()
+	var ifSeqNo, ifPrimaryTerm *int64
+	if etag := r.Header.Get("If-Match"); etag != "" {
+		seqNo, primaryTerm, err := parseEtag(etag)
+		if err != nil {
+			return apiErrorResp(apierr.ValidationFailed, fmt.Sprintf("invalid If-Match header: %v", err))
+		}
+		ifSeqNo, ifPrimaryTerm = &seqNo, &primaryTerm
+	}
+	// publishLock still guards against racing the scheduler's unconditional
+	// (nil seq_no) upsert of the same guid; the If-Match etag only protects
+	// against racing another manual request.
+	guid, _, err := parseArticleId(id)
+	if err != nil {
+		return apiErrorResp(apierr.ValidationFailed, fmt.Sprintf("invalid article id: %v", err))
+	}
 	lock := publishLock.Get(guid)
 	lock.Lock()
 	defer lock.Unlock()
-	_, err := updService.Do(ctx)
+	return publishArticleVersion(app, ctx, id, username, ifSeqNo, ifPrimaryTerm)
+}
+
+// unpublishArticleGuid deletes guid from the publish type, pinned to
+// ifSeqNo/ifPrimaryTerm when given. Shared by the /article/unpublish
+// handler (token required) and the scheduler (nil, serialized by
+// publishLock instead). actor is recorded on the resulting
+// article.unpublished event ("scheduler" for the latter).
+func unpublishArticleGuid(app *AppRuntime, ctx context.Context, guid string, actor string, ifSeqNo, ifPrimaryTerm *int64) *HttpResponseData {
+	delService := app.Elastic.Client.Delete()
+	delService.Index(app.Conf.ArticleIndex.Name)
+	delService.Type(app.Conf.ArticleIndexTypes.Publish)
+	delService.Id(guid)
+	if ifSeqNo != nil && ifPrimaryTerm != nil {
+		delService.IfSeqNo(*ifSeqNo)
+		delService.IfPrimaryTerm(*ifPrimaryTerm)
+	}
+	_, err := delService.Do(ctx)
 	if err != nil {
-		body := fmt.Sprintf("failed to publish article, error: %v", err)
-		return CreateInternalServerErrorRespData(body)
+		if elastic.IsConflict(err) {
+			return conflictRespData(app, ctx, app.Conf.ArticleIndexTypes.Publish, guid)
+		} else if elastic.IsNotFound(err) {
+			body := fmt.Sprintf("article %v not found!", guid)
+			return apiErrorResp(apierr.NotFound, body)
+		} else if d := ctxErrorResp(ctx); d != nil {
+			return d
+		} else {
+			body := fmt.Sprintf("failed to unpublish article, error: %v", err)
+			return apiErrorResp(apierr.Internal, body)
+		}
 	} else {
+		emitArticleEvent(app, events.ArticleUnpublished, guid, 0, actor, "")
 		return CreateRespData(http.StatusOK, ContentTypeValueText, "")
 	}
 }
 
 func unpublishArticle(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
 	id := StringFromReq(r, CtxKeyId)
-	delService := app.Elastic.Client.Delete()
-	delService.Index(app.Conf.ArticleIndex.Name)
-	delService.Type(app.Conf.ArticleIndexTypes.Publish)
-	delService.Id(id)
-
+	username := AuthFromReq(r).Username
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.Unpublish, DefaultUnpublishTimeout)
+	defer cancel()
+	seqNo, primaryTerm, d := requireIfMatch(r)
+	if d != nil {
+		return d
+	}
+	// publishLock still guards against racing the scheduler's unconditional
+	// (nil seq_no) delete of the same guid; the If-Match etag only protects
+	// against racing another manual request.
 	lock := publishLock.Get(id)
 	lock.Lock()
 	defer lock.Unlock()
-	_, err := delService.Do(context.Background())
+	return unpublishArticleGuid(app, ctx, id, username, &seqNo, &primaryTerm)
+}
+
+// BulkItemResult is the per-id outcome reported back from a bulk
+// endpoint, one line of newline-delimited JSON per requested id.
+type BulkItemResult struct {
+	Id     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func decodeBulkIds(r *http.Request) ([]string, *HttpResponseData) {
+	bytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, apiErrorResp(apierr.Internal, fmt.Sprintf("failed to read request body, error: %v", err))
+	}
+	var ids []string
+	if err := json.Unmarshal(bytes, &ids); err != nil {
+		return nil, apiErrorResp(apierr.ValidationFailed, fmt.Sprintf("failed to unmarshal ids, error: %v", err))
+	}
+	if len(ids) == 0 {
+		return nil, apiErrorResp(apierr.ValidationFailed, "ids must be a non-empty array!")
+	}
+	return ids, nil
+}
+
+func sortedUniqueIds(ids []string) []string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	unique := sorted[:0]
+	for i, id := range sorted {
+		if i == 0 || id != sorted[i-1] {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// lockAllSorted acquires m's per-id lock for every id, in sorted order,
+// and returns a func that releases them all in reverse order. Every
+// caller (single-item handlers and this one) locking ids in the same
+// sorted order rules out the classic deadlock where two batches overlap
+// on two ids but grab them in opposite order.
+func lockAllSorted(m *UniqStrMutex, ids []string) func() {
+	unlockFns := make([]func(), 0, len(ids))
+	for _, id := range ids {
+		lock := m.Get(id)
+		lock.Lock()
+		unlockFns = append(unlockFns, lock.Unlock)
+	}
+	return func() {
+		for i := len(unlockFns) - 1; i >= 0; i-- {
+			unlockFns[i]()
+		}
+	}
+}
+
+// newArticleBulkProcessor configures a BulkProcessor with bounded worker
+// concurrency and exponential backoff, so a batch that hits ES 429s is
+// retried transparently instead of failing the whole request. after is
+// invoked once per committed sub-batch.
+func newArticleBulkProcessor(
+	app *AppRuntime,
+	ctx context.Context,
+	name string,
+	after func(resp *elastic.BulkResponse, err error)) (*elastic.BulkProcessor, error) {
+	workers := app.Conf.Bulk.Workers
+	if workers <= 0 {
+		workers = BulkDefaultWorkers
+	}
+	actions := app.Conf.Bulk.BulkActions
+	if actions <= 0 {
+		actions = BulkDefaultActions
+	}
+	p, err := app.Elastic.Client.BulkProcessor().
+		Name(name).
+		Workers(workers).
+		BulkActions(actions).
+		Backoff(elastic.NewExponentialBackoff(BulkDefaultBackoffMin, BulkDefaultBackoffMax)).
+		After(func(executionId int64, requests []elastic.BulkableRequest, resp *elastic.BulkResponse, err error) {
+			after(resp, err)
+		}).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Start(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// bulkResultWriter streams BulkItemResult lines to w as they're produced
+// instead of buffering the whole batch, so a request for thousands of
+// ids reports progress as it happens and never holds the full response
+// body in memory. It writes the 200 + Content-Type on the first line
+// (so a mid-batch failure can no longer change the status) and flushes
+// after every line when w supports it. Safe for concurrent use since
+// BulkProcessor's After callback can fire from more than one worker.
+type bulkResultWriter struct {
+	w        http.ResponseWriter
+	mu       sync.Mutex
+	wroteHdr bool
+}
+
+func newBulkResultWriter(w http.ResponseWriter) *bulkResultWriter {
+	return &bulkResultWriter{w: w}
+}
+
+func (bw *bulkResultWriter) write(res *BulkItemResult) {
+	line, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if !bw.wroteHdr {
+		bw.w.Header().Set("Content-Type", ContentTypeValueText)
+		bw.w.WriteHeader(http.StatusOK)
+		bw.wroteHdr = true
+	}
+	bw.w.Write(line)
+	bw.w.Write([]byte("\n"))
+	if f, ok := bw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// runBulkDelete is shared by /article/bulk-unpublish and
+// /article/bulk-discard, which are both a straight per-id delete
+// against a single type, just against different locks/types/events.
+// Results are streamed to w one line per id as the bulk processor's
+// sub-batches commit, so the caller sees progress on a batch of
+// thousands of ids instead of waiting for the whole thing to finish; a
+// nil return tells
+// the caller the response was already written.
+func runBulkDelete(
+	app *AppRuntime, w http.ResponseWriter, r *http.Request,
+	opName string, lock *UniqStrMutex, typ string, eventType string,
+	confTimeout, defTimeout time.Duration) *HttpResponseData {
+	ids, d := decodeBulkIds(r)
+	if d != nil {
+		return d
+	}
+	ids = sortedUniqueIds(ids)
+	unlock := lockAllSorted(lock, ids)
+	defer unlock()
+
+	ctx, cancel := endpointContext(r, confTimeout, defTimeout)
+	defer cancel()
+	actor := AuthFromReq(r).Username
+	bw := newBulkResultWriter(w)
+	var mu sync.Mutex
+	reported := make(map[string]bool, len(ids))
+	recordResult := func(id, status, errMsg string) {
+		mu.Lock()
+		reported[id] = true
+		mu.Unlock()
+		bw.write(&BulkItemResult{Id: id, Status: status, Error: errMsg})
+		if status == "ok" {
+			emitArticleEvent(app, eventType, id, 0, actor, "")
+		}
+	}
+	p, err := newArticleBulkProcessor(app, ctx, opName, func(resp *elastic.BulkResponse, err error) {
+		if err != nil {
+			app.Logger.Perrorf("%v: bulk commit failed, error: %v", opName, err)
+			return
+		}
+		for _, item := range resp.Items {
+			for _, result := range item {
+				if result.Error != nil {
+					recordResult(result.Id, "error", result.Error.Reason)
+				} else {
+					recordResult(result.Id, "ok", "")
+				}
+			}
+		}
+	})
+	if err != nil {
+		return apiErrorResp(apierr.Internal, fmt.Sprintf("failed to start bulk processor, error: %v", err))
+	}
+	index := app.Conf.ArticleIndex.Name
+	for _, id := range ids {
+		p.Add(elastic.NewBulkDeleteRequest().Index(index).Type(typ).Id(id))
+	}
+	if err := p.Close(); err != nil {
+		app.Logger.Perrorf("%v: failed to flush bulk processor, error: %v", opName, err)
+	}
+	for _, id := range ids {
+		mu.Lock()
+		already := reported[id]
+		mu.Unlock()
+		if !already {
+			bw.write(&BulkItemResult{Id: id, Status: "error", Error: "no bulk result recorded"})
+		}
+	}
+	return nil
+}
+
+func bulkDiscardArticles(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
+	return runBulkDelete(app, w, r, "bulk-discard", draftLock, app.Conf.ArticleIndexTypes.Draft, events.ArticleDiscarded,
+		app.Conf.Timeouts.BulkDiscard, DefaultBulkDiscardTimeout)
+}
+
+func bulkUnpublishArticles(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
+	return runBulkDelete(app, w, r, "bulk-unpublish", publishLock, app.Conf.ArticleIndexTypes.Publish, events.ArticleUnpublished,
+		app.Conf.Timeouts.BulkUnpublish, DefaultBulkUnpublishTimeout)
+}
+
+// bulkPublishArticles is the bulk form of /article/publish: unlike
+// discard/unpublish it can't build its ES requests from the id alone,
+// since publishing means reading the full version and upserting a
+// transformed doc into the publish type, so ids are resolved to guids
+// up front. Locking happens on the resolved, sorted-unique guids rather
+// than the raw (version-qualified) ids: the scheduler and bulk/single
+// unpublish all key publishLock by guid, and locking by id here
+// wouldn't serialize against any of them. Results stream to w one line
+// per id as they're produced; a nil return tells the caller the
+// response was already written.
+func bulkPublishArticles(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
+	ids, d := decodeBulkIds(r)
+	if d != nil {
+		return d
+	}
+	ids = sortedUniqueIds(ids)
+
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.BulkPublish, DefaultBulkPublishTimeout)
+	defer cancel()
+	actor := AuthFromReq(r).Username
+	client := app.Elastic.Client
+	index := app.Conf.ArticleIndex.Name
+	versionTyp := app.Conf.ArticleIndexTypes.Version
+	publishTyp := app.Conf.ArticleIndexTypes.Publish
+
+	// Resolve every id to its guid before writing anything to w, so a
+	// failure to even start the bulk processor can still come back as a
+	// normal apiErrorResp instead of fighting a response already in
+	// flight. Two ids resolving to the same guid (two pending versions
+	// of one article) can't both be satisfied by one _bulk doc id, so
+	// that's rejected here rather than silently publishing one and
+	// dropping the other.
+	type resolved struct {
+		id      string
+		guid    string
+		article *Article
+	}
+	toPublish := make([]resolved, 0, len(ids))
+	var resolveErrs []*BulkItemResult
+	byGuid := make(map[string]resolved, len(ids))
+	for _, id := range ids {
+		article, d := getFullArticle(client, ctx, index, versionTyp, id)
+		if d != nil {
+			resolveErrs = append(resolveErrs, &BulkItemResult{Id: id, Status: "error", Error: fmt.Sprintf("failed to load version, status %v", d.Status)})
+			continue
+		}
+		if prior, ok := byGuid[article.Guid]; ok {
+			return apiErrorResp(apierr.ValidationFailed, fmt.Sprintf("ids %v and %v both resolve to guid %v; publish them one at a time", prior.id, id, article.Guid))
+		}
+		rp := resolved{id: id, guid: article.Guid, article: article}
+		byGuid[article.Guid] = rp
+		toPublish = append(toPublish, rp)
+	}
+	guids := make([]string, 0, len(byGuid))
+	for guid := range byGuid {
+		guids = append(guids, guid)
+	}
+	unlock := lockAllSorted(publishLock, sortedUniqueIds(guids))
+	defer unlock()
+
+	bw := newBulkResultWriter(w)
+	var mu sync.Mutex
+	reported := make(map[string]bool, len(ids))
+	recordResult := func(id, status, errMsg string) {
+		mu.Lock()
+		reported[id] = true
+		mu.Unlock()
+		bw.write(&BulkItemResult{Id: id, Status: status, Error: errMsg})
+	}
+	idForGuid := make(map[string]string, len(toPublish))
+	recordGuidResult := func(guid, status, errMsg string, seqNo, primaryTerm int64) {
+		mu.Lock()
+		id, ok := idForGuid[guid]
+		mu.Unlock()
+		if !ok {
+			id = guid
+		}
+		recordResult(id, status, errMsg)
+		if status == "ok" {
+			if rp, ok := byGuid[guid]; ok {
+				emitArticleEvent(app, events.ArticlePublished, guid, rp.article.Version, actor, etagFor(seqNo, primaryTerm))
+			}
+		}
+	}
+	p, err := newArticleBulkProcessor(app, ctx, "bulk-publish", func(resp *elastic.BulkResponse, err error) {
+		if err != nil {
+			app.Logger.Perrorf("bulk-publish: bulk commit failed, error: %v", err)
+			return
+		}
+		for _, item := range resp.Items {
+			for _, result := range item {
+				if result.Error != nil {
+					recordGuidResult(result.Id, "error", result.Error.Reason, 0, 0)
+				} else {
+					recordGuidResult(result.Id, "ok", "", result.SeqNo, result.PrimaryTerm)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return apiErrorResp(apierr.Internal, fmt.Sprintf("failed to start bulk processor, error: %v", err))
+	}
+	for _, res := range resolveErrs {
+		recordResult(res.Id, res.Status, res.Error)
+	}
+	for _, rp := range toPublish {
+		article := rp.article
+		article.Id = rp.guid
+		article.LockedBy = ""
+		mu.Lock()
+		idForGuid[rp.guid] = rp.id
+		mu.Unlock()
+		p.Add(elastic.NewBulkUpdateRequest().Index(index).Type(publishTyp).Id(rp.guid).Doc(article).DocAsUpsert(true))
+	}
+	if err := p.Close(); err != nil {
+		app.Logger.Perrorf("bulk-publish: failed to flush bulk processor, error: %v", err)
+	}
+	for _, rp := range toPublish {
+		mu.Lock()
+		already := reported[rp.id]
+		mu.Unlock()
+		if !already {
+			bw.write(&BulkItemResult{Id: rp.id, Status: "error", Error: "no bulk result recorded"})
+		}
+	}
+	return nil
+}
+
+// scheduleArticlePublish sets publish_at on a version so the scheduler
+// publishes it once due. Expects a JSON body of {"publish_at": "..."}.
+func scheduleArticlePublish(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
+	bytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		body := fmt.Sprintf("failed to read request body, error: %v", err)
+		return apiErrorResp(apierr.Internal, body)
+	}
+	var req struct {
+		PublishAt *JSONTime `json:"publish_at"`
+	}
+	if err := json.Unmarshal(bytes, &req); err != nil {
+		body := fmt.Sprintf("failed to unmarshal request body, error: %v", err)
+		return apiErrorResp(apierr.Internal, body)
+	}
+	if req.PublishAt == nil {
+		return apiErrorResp(apierr.ValidationFailed, "publish_at is required!")
+	}
+	id := StringFromReq(r, CtxKeyId)
+	guid, _, err := parseArticleId(id)
+	if err != nil {
+		body := fmt.Sprintf("failed to parse article id %v, error: %v", id, err)
+		return apiErrorResp(apierr.Internal, body)
+	}
+	script := elastic.NewScript(ESScriptSetPublishAt)
+	script.Type("inline").Lang("painless").Params(map[string]interface{}{
+		"publish_at": req.PublishAt,
+	})
+	updService := app.Elastic.Client.Update()
+	updService.Index(app.Conf.ArticleIndex.Name)
+	updService.Type(app.Conf.ArticleIndexTypes.Version)
+	updService.Id(id)
+	updService.Script(script)
+
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.SchedulePublish, DefaultSchedulePublishTimeout)
+	defer cancel()
+	lock := publishLock.Get(guid)
+	lock.Lock()
+	defer lock.Unlock()
+	_, err = updService.Do(ctx)
 	if err != nil {
 		if elastic.IsNotFound(err) {
 			body := fmt.Sprintf("article %v not found!", id)
-			return CreateNotFoundRespData(body)
+			return apiErrorResp(apierr.NotFound, body)
+		} else if d := ctxErrorResp(ctx); d != nil {
+			return d
 		} else {
-			body := fmt.Sprintf("failed to unpublish article, error: %v", err)
-			return CreateInternalServerErrorRespData(body)
+			body := fmt.Sprintf("failed to schedule publish for article %v, error: %v", id, err)
+			return apiErrorResp(apierr.Internal, body)
 		}
+	}
+	return CreateRespData(http.StatusOK, ContentTypeValueText, "")
+}
+
+// scheduleArticleUnpublish sets unpublish_at on a published article so
+// the scheduler retracts it once due. Expects a JSON body of
+// {"unpublish_at": "..."}.
+func scheduleArticleUnpublish(app *AppRuntime, w http.ResponseWriter, r *http.Request) *HttpResponseData {
+	bytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		body := fmt.Sprintf("failed to read request body, error: %v", err)
+		return apiErrorResp(apierr.Internal, body)
+	}
+	var req struct {
+		UnpublishAt *JSONTime `json:"unpublish_at"`
+	}
+	if err := json.Unmarshal(bytes, &req); err != nil {
+		body := fmt.Sprintf("failed to unmarshal request body, error: %v", err)
+		return apiErrorResp(apierr.Internal, body)
+	}
+	if req.UnpublishAt == nil {
+		return apiErrorResp(apierr.ValidationFailed, "unpublish_at is required!")
+	}
+	guid := StringFromReq(r, CtxKeyId)
+	script := elastic.NewScript(ESScriptSetUnpublishAt)
+	script.Type("inline").Lang("painless").Params(map[string]interface{}{
+		"unpublish_at": req.UnpublishAt,
+	})
+	updService := app.Elastic.Client.Update()
+	updService.Index(app.Conf.ArticleIndex.Name)
+	updService.Type(app.Conf.ArticleIndexTypes.Publish)
+	updService.Id(guid)
+	updService.Script(script)
+
+	ctx, cancel := endpointContext(r, app.Conf.Timeouts.ScheduleUnpublish, DefaultScheduleUnpublishTimeout)
+	defer cancel()
+	lock := publishLock.Get(guid)
+	lock.Lock()
+	defer lock.Unlock()
+	_, err = updService.Do(ctx)
+	if err != nil {
+		if elastic.IsNotFound(err) {
+			body := fmt.Sprintf("article %v not found!", guid)
+			return apiErrorResp(apierr.NotFound, body)
+		} else if d := ctxErrorResp(ctx); d != nil {
+			return d
+		} else {
+			body := fmt.Sprintf("failed to schedule unpublish for article %v, error: %v", guid, err)
+			return apiErrorResp(apierr.Internal, body)
+		}
+	}
+	return CreateRespData(http.StatusOK, ContentTypeValueText, "")
+}
+
+// RunArticleScheduler periodically scans for due publish_at/unpublish_at
+// entries and fires them through the same publish/unpublish path as
+// manual requests. It processes any past-due entries immediately on
+// startup to cover time the process was down, then ticks on interval.
+// It stops when ctx is cancelled (see AppRuntime.Shutdown).
+func (app *AppRuntime) RunArticleScheduler(ctx context.Context) {
+	interval := app.Conf.ArticleScheduler.Interval
+	if interval <= 0 {
+		interval = ArticleSchedulerDefaultInterval
+	}
+	app.scanDuePublishes(ctx)
+	app.scanDueUnpublishes(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			app.scanDuePublishes(ctx)
+			app.scanDueUnpublishes(ctx)
+		}
+	}
+}
+
+func (app *AppRuntime) scanDuePublishes(ctx context.Context) {
+	client := app.Elastic.Client
+	now := &JSONTime{time.Now().UTC()}
+	query := elastic.NewRangeQuery("publish_at").Lte(now)
+	searchService := client.Search()
+	searchService.Index(app.Conf.ArticleIndex.Name)
+	searchService.Type(app.Conf.ArticleIndexTypes.Version)
+	searchService.Query(query)
+	searchService.Size(ArticleSchedulerBatchSize)
+	resp, err := searchService.Do(ctx)
+	if err != nil {
+		app.Logger.Perrorf("article scheduler: failed to scan due publish_at entries, error: %v", err)
+		return
+	}
+	for _, hit := range resp.Hits.Hits {
+		article := &Article{}
+		if err := json.Unmarshal(*hit.Source, article); err != nil {
+			app.Logger.Perrorf("article scheduler: failed to unmarshal version %v, error: %v", hit.Id, err)
+			continue
+		}
+		guid := article.Guid
+		lock := publishLock.Get(guid)
+		lock.Lock()
+		d := publishArticleVersion(app, ctx, hit.Id, "scheduler", nil, nil)
+		if d == nil || d.Status == http.StatusOK {
+			app.clearScheduledField(ctx, app.Conf.ArticleIndexTypes.Version, hit.Id, ESScriptClearPublishAt, "publish_at", article.PublishAt)
+		} else {
+			app.Logger.Perrorf("article scheduler: failed to publish due article %v, status %v", hit.Id, d.Status)
+		}
+		lock.Unlock()
+	}
+}
+
+func (app *AppRuntime) scanDueUnpublishes(ctx context.Context) {
+	client := app.Elastic.Client
+	now := &JSONTime{time.Now().UTC()}
+	query := elastic.NewRangeQuery("unpublish_at").Lte(now)
+	searchService := client.Search()
+	searchService.Index(app.Conf.ArticleIndex.Name)
+	searchService.Type(app.Conf.ArticleIndexTypes.Publish)
+	searchService.Query(query)
+	searchService.Size(ArticleSchedulerBatchSize)
+	resp, err := searchService.Do(ctx)
+	if err != nil {
+		app.Logger.Perrorf("article scheduler: failed to scan due unpublish_at entries, error: %v", err)
+		return
+	}
+	for _, hit := range resp.Hits.Hits {
+		article := &Article{}
+		if err := json.Unmarshal(*hit.Source, article); err != nil {
+			app.Logger.Perrorf("article scheduler: failed to unmarshal publish %v, error: %v", hit.Id, err)
+			continue
+		}
+		guid := hit.Id
+		lock := publishLock.Get(guid)
+		lock.Lock()
+		d := unpublishArticleGuid(app, ctx, guid, "scheduler", nil, nil)
+		if d == nil || d.Status == http.StatusOK {
+			app.clearScheduledField(ctx, app.Conf.ArticleIndexTypes.Publish, guid, ESScriptClearUnpublishAt, "unpublish_at", article.UnpublishAt)
+		} else {
+			app.Logger.Perrorf("article scheduler: failed to unpublish due article %v, status %v", guid, d.Status)
+		}
+		lock.Unlock()
+	}
+}
+
+// clearScheduledField clears a scheduled publish_at/unpublish_at field
+// once it has fired, scoped to the value the scan observed so requests
+// stay idempotent even if a new schedule races in concurrently.
+func (app *AppRuntime) clearScheduledField(ctx context.Context, typ, id, scriptSrc, field string, expected *JSONTime) {
+	script := elastic.NewScript(scriptSrc)
+	script.Type("inline").Lang("painless").Params(map[string]interface{}{
+		"expected": expected,
+	})
+	updService := app.Elastic.Client.Update()
+	updService.Index(app.Conf.ArticleIndex.Name)
+	updService.Type(typ)
+	updService.Id(id)
+	updService.Script(script)
+	if _, err := updService.Do(ctx); err != nil {
+		app.Logger.Perrorf("article scheduler: failed to clear %v on %v, error: %v", field, id, err)
+	}
+}
+
+// eventBackoff returns the delay before the next delivery attempt for
+// an event that has failed attempts times, doubling from
+// ArticleEventBackoffMin up to ArticleEventBackoffMax.
+func eventBackoff(attempts int) time.Duration {
+	d := ArticleEventBackoffMin
+	for i := 0; i < attempts; i++ {
+		d *= 2
+		if d >= ArticleEventBackoffMax {
+			return ArticleEventBackoffMax
+		}
+	}
+	return d
+}
+
+// RunArticleEventDispatcher scans the article_events outbox on a timer
+// and retries delivery of anything still undelivered to app.EventSinks
+// (webhooks, and optionally a Kafka/NATS topic sink), the same
+// catch-up-then-tick pattern as RunArticleScheduler.
+func (app *AppRuntime) RunArticleEventDispatcher(ctx context.Context) {
+	interval := app.Conf.ArticleEventDispatcher.Interval
+	if interval <= 0 {
+		interval = ArticleEventDispatcherDefaultInterval
+	}
+	app.scanPendingEvents(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			app.scanPendingEvents(ctx)
+		}
+	}
+}
+
+// scanPendingEvents finds outbox rows due for a delivery attempt and
+// hands each to deliverEvent.
+func (app *AppRuntime) scanPendingEvents(ctx context.Context) {
+	client := app.Elastic.Client
+	now := time.Now().UTC()
+	query := elastic.NewBoolQuery().
+		Must(elastic.NewRangeQuery("next_attempt_at").Lte(now.Format(time.RFC3339Nano))).
+		MustNot(elastic.NewExistsQuery("delivered_at")).
+		MustNot(elastic.NewExistsQuery("dead_lettered_at"))
+	searchService := client.Search()
+	searchService.Index(app.Conf.ArticleEventsIndex.Name)
+	searchService.Type(app.Conf.ArticleEventsIndex.Type)
+	searchService.Query(query)
+	searchService.Sort("next_attempt_at", true)
+	searchService.Size(ArticleEventDispatcherBatchSize)
+	resp, err := searchService.Do(ctx)
+	if err != nil {
+		app.Logger.Perrorf("event dispatcher: failed to scan article_events, error: %v", err)
+		return
+	}
+	for _, hit := range resp.Hits.Hits {
+		e := &events.Event{}
+		if err := json.Unmarshal(*hit.Source, e); err != nil {
+			app.Logger.Perrorf("event dispatcher: failed to unmarshal event %v, error: %v", hit.Id, err)
+			continue
+		}
+		app.deliverEvent(ctx, hit.Id, e)
+	}
+}
+
+// deliverEvent pushes e to every sink in app.EventSinks, in order,
+// stopping at the first failure. On full success it marks the outbox
+// row delivered; on failure it bumps attempts and schedules the next
+// retry with exponential backoff, so a webhook receiver being down
+// delays but never loses the event. Once attempts reaches
+// ArticleEventMaxAttempts the row is dead-lettered instead of
+// rescheduled, so a permanently failing sink can't wedge the dispatcher
+// into retrying the same row forever; scanPendingEvents excludes
+// dead-lettered rows from future scans.
+func (app *AppRuntime) deliverEvent(ctx context.Context, id string, e *events.Event) {
+	var failed error
+	for _, sink := range app.EventSinks {
+		if err := sink.Deliver(ctx, e); err != nil {
+			failed = fmt.Errorf("sink %v: %v", sink.Name(), err)
+			break
+		}
+	}
+	now := time.Now().UTC()
+	updService := app.Elastic.Client.Update()
+	updService.Index(app.Conf.ArticleEventsIndex.Name)
+	updService.Type(app.Conf.ArticleEventsIndex.Type)
+	updService.Id(id)
+	if failed == nil {
+		script := elastic.NewScript(ESScriptMarkEventDelivered)
+		script.Type("inline").Lang("painless").Params(map[string]interface{}{
+			"now": now.Format(time.RFC3339Nano),
+		})
+		updService.Script(script)
 	} else {
-		return CreateRespData(http.StatusOK, ContentTypeValueText, "")
+		attempts := e.Attempts + 1
+		if attempts >= ArticleEventMaxAttempts {
+			app.Logger.Perrorf("event dispatcher: dead-lettering %v event for %v after %v attempts, last error: %v", e.Type, e.GUID, attempts, failed)
+			script := elastic.NewScript(ESScriptDeadLetterEvent)
+			script.Type("inline").Lang("painless").Params(map[string]interface{}{
+				"attempts": attempts,
+				"now":      now.Format(time.RFC3339Nano),
+			})
+			updService.Script(script)
+		} else {
+			app.Logger.Perrorf("event dispatcher: delivery of %v event for %v failed, error: %v", e.Type, e.GUID, failed)
+			script := elastic.NewScript(ESScriptRetryEvent)
+			script.Type("inline").Lang("painless").Params(map[string]interface{}{
+				"attempts":        attempts,
+				"next_attempt_at": now.Add(eventBackoff(attempts)).Format(time.RFC3339Nano),
+			})
+			updService.Script(script)
+		}
+	}
+	if _, err := updService.Do(ctx); err != nil {
+		app.Logger.Perrorf("event dispatcher: failed to update outbox row %v, error: %v", id, err)
 	}
 }
 
@@ -570,3 +1937,33 @@ func ArticleUnpublish(app *AppRuntime) EndpointHandler {
 	h := addAuditLogFields("unpublish", unpublishArticle)
 	return GetRequiredStringArg("id", CtxKeyId, h)
 }
+
+func ArticleSchedulePublish(app *AppRuntime) EndpointHandler {
+	h := addAuditLogFields("schedule-publish", scheduleArticlePublish)
+	return GetRequiredStringArg("id", CtxKeyId, h)
+}
+
+func ArticleScheduleUnpublish(app *AppRuntime) EndpointHandler {
+	h := addAuditLogFields("schedule-unpublish", scheduleArticleUnpublish)
+	return GetRequiredStringArg("id", CtxKeyId, h)
+}
+
+func ArticleBulkPublish(app *AppRuntime) EndpointHandler {
+	return addAuditLogFields("bulk-publish", bulkPublishArticles)
+}
+
+func ArticleBulkUnpublish(app *AppRuntime) EndpointHandler {
+	return addAuditLogFields("bulk-unpublish", bulkUnpublishArticles)
+}
+
+func ArticleBulkDiscard(app *AppRuntime) EndpointHandler {
+	return addAuditLogFields("bulk-discard", bulkDiscardArticles)
+}
+
+func ArticleHistory(app *AppRuntime) EndpointHandler {
+	return addAuditLogFields("history", articleHistory)
+}
+
+func ArticleDiff(app *AppRuntime) EndpointHandler {
+	return addAuditLogFields("diff", articleDiff)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	cases := []struct {
+		name string
+		a    string
+		b    string
+		want []*DiffRun
+	}{
+		{
+			name: "identical",
+			a:    "a\nb\nc",
+			b:    "a\nb\nc",
+			want: []*DiffRun{{Op: DiffOpEq, Text: "a\nb\nc"}},
+		},
+		{
+			name: "insert after common prefix",
+			a:    "a\nc",
+			b:    "a\nb\nc",
+			want: []*DiffRun{
+				{Op: DiffOpEq, Text: "a"},
+				{Op: DiffOpIns, Text: "b"},
+				{Op: DiffOpEq, Text: "c"},
+			},
+		},
+		{
+			name: "delete after common prefix",
+			a:    "a\nb\nc",
+			b:    "a\nc",
+			want: []*DiffRun{
+				{Op: DiffOpEq, Text: "a"},
+				{Op: DiffOpDel, Text: "b"},
+				{Op: DiffOpEq, Text: "c"},
+			},
+		},
+		{
+			name: "common prefix with no common suffix",
+			a:    "a\nb",
+			b:    "a\nc",
+			want: []*DiffRun{
+				{Op: DiffOpEq, Text: "a"},
+				{Op: DiffOpDel, Text: "b"},
+				{Op: DiffOpIns, Text: "c"},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := diffLines(c.a, c.b)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("diffLines(%q, %q) = %+v, want %+v", c.a, c.b, got, c.want)
+			}
+		})
+	}
+}
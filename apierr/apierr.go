@@ -0,0 +1,89 @@
+// Package apierr defines the typed error model returned by story-api's
+// HTTP handlers, so clients get a stable {"error":{"code":...}} contract
+// instead of parsing English error strings.
+package apierr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type Code string
+
+const (
+	ValidationFailed Code = "VALIDATION_FAILED"
+	NotFound         Code = "NOT_FOUND"
+	Conflict         Code = "CONFLICT"
+	LockedByOther    Code = "LOCKED_BY_OTHER"
+	Internal         Code = "INTERNAL"
+	ESUnavailable    Code = "ES_UNAVAILABLE"
+	Unauthenticated  Code = "UNAUTHENTICATED"
+	NoPermission     Code = "NO_PERMISSION"
+	DeadlineExceeded Code = "DEADLINE_EXCEEDED"
+	Canceled         Code = "CANCELED"
+)
+
+// clientClosedRequest is the nginx-originated 499 used for a request the
+// client disconnected before the server could respond; net/http has no
+// named constant for it.
+const clientClosedRequest = 499
+
+var httpStatusByCode = map[Code]int{
+	ValidationFailed: http.StatusBadRequest,
+	NotFound:         http.StatusNotFound,
+	Conflict:         http.StatusConflict,
+	LockedByOther:    http.StatusForbidden,
+	Internal:         http.StatusInternalServerError,
+	ESUnavailable:    http.StatusServiceUnavailable,
+	Unauthenticated:  http.StatusUnauthorized,
+	NoPermission:     http.StatusForbidden,
+	DeadlineExceeded: http.StatusGatewayTimeout,
+	Canceled:         clientClosedRequest,
+}
+
+// Error is both the wire model (marshaled under an "error" envelope key)
+// and a Go error, so handlers can return it through normal error paths
+// as well as through the HTTP response.
+type Error struct {
+	Code      Code                   `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// HTTPStatus maps the typed code to the HTTP status the handler should
+// respond with; unknown codes fall back to 500.
+func (e *Error) HTTPStatus() int {
+	if status, ok := httpStatusByCode[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	e.Details = details
+	return e
+}
+
+func (e *Error) WithRequestID(id string) *Error {
+	e.RequestID = id
+	return e
+}
+
+type envelope struct {
+	Error *Error `json:"error"`
+}
+
+// MarshalEnvelope renders e as the {"error":{...}} body sent to clients.
+func (e *Error) MarshalEnvelope() ([]byte, error) {
+	return json.Marshal(&envelope{Error: e})
+}
@@ -0,0 +1,125 @@
+// Package events defines the lifecycle-event payload emitted by the
+// article workflow and the sinks it can be delivered to. It has no
+// dependency on AppRuntime or elastic so it can be unit tested and
+// reused by anything that needs to fan an Event out to a webhook or a
+// message-bus topic; persisting the outbox row and retrying failed
+// deliveries is the caller's job (see RunArticleEventDispatcher).
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	ArticleCreated     = "article.created"
+	ArticleSaved       = "article.saved"
+	ArticleSubmitted   = "article.submitted"
+	ArticleDiscarded   = "article.discarded"
+	ArticleEdited      = "article.edited"
+	ArticlePublished   = "article.published"
+	ArticleUnpublished = "article.unpublished"
+)
+
+// SignatureHeader is the HTTP header a webhook receiver must check the
+// HMAC-SHA256 signature of the raw request body against.
+const SignatureHeader = "X-Story-Signature"
+
+// Event is both the outbox row persisted to the article_events index and
+// the payload delivered to sinks.
+type Event struct {
+	Type           string     `json:"type"`
+	GUID           string     `json:"guid"`
+	Version        int64      `json:"version,omitempty"`
+	Actor          string     `json:"actor"`
+	Timestamp      time.Time  `json:"timestamp"`
+	ETag           string     `json:"etag,omitempty"`
+	Attempts       int        `json:"attempts"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty"`
+}
+
+// Sink delivers a single Event to one destination. Implementations must
+// be safe to retry: the dispatcher calls Deliver again with the same
+// Event after a backoff whenever it returns an error.
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, e *Event) error
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 of payload under secret, the
+// value sent in the SignatureHeader so receivers can verify authenticity.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookSink POSTs the event as JSON to URL, signing the body with
+// Secret so the receiver can verify it came from story-api.
+type WebhookSink struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func (s *WebhookSink) Name() string {
+	return fmt.Sprintf("webhook:%s", s.URL)
+}
+
+func (s *WebhookSink) Deliver(ctx context.Context, e *Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(s.Secret, payload))
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook delivery failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook receiver returned status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// TopicSink publishes the event to a message-bus topic (Kafka, NATS,
+// ...). Publish is supplied by whoever wires up the dispatcher so this
+// package doesn't need to depend on a specific client library.
+type TopicSink struct {
+	Topic   string
+	Publish func(ctx context.Context, topic string, payload []byte) error
+}
+
+func (s *TopicSink) Name() string {
+	return fmt.Sprintf("topic:%s", s.Topic)
+}
+
+func (s *TopicSink) Deliver(ctx context.Context, e *Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %v", err)
+	}
+	if s.Publish == nil {
+		return fmt.Errorf("topic sink %v has no publisher configured", s.Topic)
+	}
+	return s.Publish(ctx, s.Topic, payload)
+}